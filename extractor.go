@@ -2,9 +2,15 @@ package generate
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"errors"
 
@@ -14,55 +20,699 @@ import (
 // Extractor will produce meta types from the JSON schema.
 type Extractor struct {
 	schema *jsonschema.Schema
+
+	// typeNames overrides the golang name ImportType would otherwise derive
+	// from a reflect.Type, set via TypeName.
+	typeNames map[reflect.Type]string
+	// fieldBlacklist excludes golang field names from every struct ImportType
+	// generates, set via BlacklistField.
+	fieldBlacklist map[string]bool
+
+	// FormatMapping overrides the golang type a string format keyword maps
+	// to, keyed by the JSON schema "format" value, e.g. "uuid" -> "uuid.UUID".
+	// Checked before the built-in date-time/byte/binary/uri mappings, so it
+	// can override those too.
+	FormatMapping map[string]string
+
+	// Resolver loads the schema behind a $ref CreateStructs can't resolve
+	// locally, e.g. one pointing at a sibling file or a URL. Left nil, such
+	// refs are left unresolved just as before.
+	Resolver RefResolver
+	// refCache holds every schema Resolver has already fetched, keyed by the
+	// $ref that produced it, so the same ref is never fetched twice.
+	refCache map[string]*jsonschema.Schema
+}
+
+// RefResolver loads the schema a $ref points at when CreateStructs can't find
+// it among the types already extracted from the root schema.
+type RefResolver interface {
+	Resolve(ref string) (*jsonschema.Schema, error)
+}
+
+// defaultFormatMapping seeds Extractor.FormatMapping with the formats this
+// package can't infer a golang type for on its own.
+var defaultFormatMapping = map[string]string{
+	"uuid": "uuid.UUID",
 }
 
 // New creates an instance of a Extractor which will produce meta types.
 func New(schema *jsonschema.Schema) *Extractor {
+	mapping := make(map[string]string, len(defaultFormatMapping))
+	for k, v := range defaultFormatMapping {
+		mapping[k] = v
+	}
+
 	return &Extractor{
-		schema: schema,
+		schema:        schema,
+		FormatMapping: mapping,
 	}
 }
 
-// CreateStructs creates meta types from the JSON schema, keyed by the golang name.
-func (g *Extractor) CreateStructs() (map[string]StructMeta, []SliceMeta, error) {
+// TypeName registers an override name for t, so ImportType emits name
+// instead of t's own golang type name when generating its StructMeta.
+func (g *Extractor) TypeName(t reflect.Type, name string) {
+	if g.typeNames == nil {
+		g.typeNames = map[reflect.Type]string{}
+	}
+
+	g.typeNames[t] = name
+}
+
+// BlacklistField excludes fields with this golang name from every struct
+// ImportType generates, e.g. to drop embedded sync.Mutex or similar fields.
+func (g *Extractor) BlacklistField(name string) {
+	if g.fieldBlacklist == nil {
+		g.fieldBlacklist = map[string]bool{}
+	}
+
+	g.fieldBlacklist[name] = true
+}
+
+// ImportType walks obj's reflect.Type (structs, slices, maps, pointers,
+// embedded fields and json tags) and produces the same StructMeta/FieldMeta/
+// SliceMeta values the JSON schema path emits, so downstream template code
+// can share a single meta model regardless of whether the input was JSON
+// Schema or Go source. Additional root types can be supplied via overrides,
+// useful for interface{} fields reflection alone can't reach.
+func (g *Extractor) ImportType(obj interface{}, overrides ...interface{}) (map[string]StructMeta, []SliceMeta, error) {
+	structs := map[string]StructMeta{}
 	var slices []SliceMeta
+	errs := []error{}
+	seen := map[reflect.Type]bool{}
+
+	roots := append([]interface{}{obj}, overrides...)
+
+	for _, root := range roots {
+		t := reflect.TypeOf(root)
+		if t == nil {
+			errs = append(errs, errors.New("can't import a nil type"))
+			continue
+		}
+
+		g.importType(t, structs, &slices, seen, &errs)
+	}
+
+	if len(errs) > 0 {
+		return structs, slices, errors.New(joinErrors(errs))
+	}
+
+	return structs, slices, nil
+}
+
+// importType resolves t, recursing into struct fields, slice/array elements
+// and map values, and returns the golang type name to use for t. Structs are
+// recorded into structs as a side effect; seen guards against infinite
+// recursion on self-referential types.
+func (g *Extractor) importType(t reflect.Type, structs map[string]StructMeta, slices *[]SliceMeta, seen map[reflect.Type]bool, errs *[]error) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if name, ok := importLeafTypeName(t); ok {
+		return name
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		elemName := g.importType(t.Elem(), structs, slices, seen, errs)
+		*slices = append(*slices, SliceMeta{ElemType: elemName})
+
+		return "[]" + elemName
+	case reflect.Map:
+		keyType := t.Key()
+		if keyType.Kind() != reflect.String {
+			*errs = append(*errs, fmt.Errorf("can't import map with non-string key type %s", keyType))
+			return "undefined"
+		}
+
+		valueName := g.importType(t.Elem(), structs, slices, seen, errs)
+
+		return "map[string]" + valueName
+	case reflect.Struct:
+		return g.importStruct(t, structs, slices, seen, errs)
+	case reflect.Interface:
+		// An interface{} field (most commonly plain interface{} itself) has
+		// no fixed schema reflection can derive, so it passes through as a
+		// raw golang interface{} rather than erroring; overrides lets callers
+		// supply the concrete types such a field can actually hold.
+		return "interface{}"
+	default:
+		name, err := getPrimitiveTypeName(goKindToSchemaType(t.Kind()), "", "", nil, false)
+		if err != nil {
+			*errs = append(*errs, err)
+		}
 
-	// Extract nested and complex types from the JSON schema.
+		return name
+	}
+}
+
+func (g *Extractor) importStruct(t reflect.Type, structs map[string]StructMeta, slices *[]SliceMeta, seen map[reflect.Type]bool, errs *[]error) string {
+	name := g.nameForType(t)
+
+	if seen[t] {
+		return name
+	}
+	seen[t] = true
+
+	fields := map[string]FieldMeta{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if g.fieldBlacklist[f.Name] {
+			continue
+		}
+
+		if f.Anonymous {
+			embeddedType := f.Type
+			for embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+
+			if embeddedType.Kind() == reflect.Struct {
+				g.importType(embeddedType, structs, slices, seen, errs)
+
+				if embedded, ok := structs[g.nameForType(embeddedType)]; ok {
+					for fn, fm := range embedded.Fields {
+						fields[fn] = fm
+					}
+				}
+				continue
+			}
+		}
+
+		jsonName, required := jsonFieldName(f)
+		if jsonName == "-" {
+			continue
+		}
+
+		fieldType := g.importType(f.Type, structs, slices, seen, errs)
+		if isPointerToStruct(f.Type) {
+			fieldType = "*" + fieldType
+		}
+
+		fields[f.Name] = FieldMeta{
+			Name:     f.Name,
+			JSONName: jsonName,
+			Type:     fieldType,
+			Required: required,
+		}
+	}
+
+	structs[name] = StructMeta{
+		ID:      "go:" + t.PkgPath() + "." + t.Name(),
+		Name:    name,
+		Fields:  fields,
+		Imports: importsForFields(fields),
+	}
+
+	return name
+}
+
+func (g *Extractor) nameForType(t reflect.Type) string {
+	if name, ok := g.typeNames[t]; ok {
+		return name
+	}
+
+	return t.Name()
+}
+
+func isPointerToStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr {
+		return false
+	}
+
+	return t.Elem().Kind() == reflect.Struct
+}
+
+// jsonFieldName reads f's json tag, returning the JSON name to use (falling
+// back to f.Name when untagged) and whether the field is required, i.e. not
+// marked omitempty. A tag of "-" means the field should be skipped entirely.
+func jsonFieldName(f reflect.StructField) (name string, required bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "-", false
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			return name, false
+		}
+	}
+
+	return name, true
+}
+
+// leafTypeNames lists the concrete reflect.Types that ImportType treats as
+// opaque leaves rather than walking into their fields, because they already
+// have a well-known golang representation.
+var leafTypeNames = map[reflect.Type]string{
+	reflect.TypeOf(time.Time{}):       "time.Time",
+	reflect.TypeOf(url.URL{}):         "url.URL",
+	reflect.TypeOf(net.IP{}):          "net.IP",
+	reflect.TypeOf(json.RawMessage{}): "json.RawMessage",
+}
+
+func importLeafTypeName(t reflect.Type) (string, bool) {
+	name, ok := leafTypeNames[t]
+	return name, ok
+}
+
+// goKindToSchemaType maps a reflect.Kind onto the JSON-schema type name
+// getPrimitiveTypeName expects, so both the schema-driven and reflection-
+// driven extraction paths agree on primitive golang type names.
+func goKindToSchemaType(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	}
+
+	return ""
+}
+
+// CreateStructs creates meta types from the JSON schema, keyed by the golang
+// name. Schemas composed with oneOf/anyOf are emitted as InterfaceMeta, keyed
+// by the golang name, rather than as structs. Extraction runs in two passes:
+// the first collects every schema by JSON Pointer, including those reached
+// through external $refs via Resolver; the second resolves references and
+// emits StructMeta in the topological Order recorded alongside it, with
+// reference cycles broken by the pointer fields getTypeForField already
+// emits for object references.
+func (g *Extractor) CreateStructs() (map[string]StructMeta, []SliceMeta, map[string]InterfaceMeta, []EnumMeta, []string, error) {
+	var slices []SliceMeta
+	var enums []EnumMeta
+	interfaces := map[string]InterfaceMeta{}
+
+	// Pass 1: collect every schema keyed by JSON Pointer, including ones
+	// reached only through an external $ref.
 	types := g.schema.ExtractTypes()
 	structs := make(map[string]StructMeta, len(types))
 
 	errs := []error{}
 
+	if err := g.resolveExternalRefs(types); err != nil {
+		errs = append(errs, err)
+	}
+
+	typeKeyToName := map[string]string{}
+
+	// Pass 2: resolve references and emit a StructMeta or InterfaceMeta for
+	// every collected schema.
 	for _, typeKey := range getOrderedKeyNamesFromSchemaMap(types) {
 		v := types[typeKey]
 
-		fields, _slices, err := getFields(typeKey, v.Properties, types, v.Required)
+		if len(v.OneOf) > 0 || len(v.AnyOf) > 0 {
+			im, err := getInterfaceMeta(typeKey, v, types)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			interfaces[im.Name] = im
+			typeKeyToName[typeKey] = im.Name
+			continue
+		}
+
+		fields, _slices, _enums, err := getFields(typeKey, v.Properties, types, v.Required, g.FormatMapping)
 		slices = append(slices, _slices...)
+		enums = append(enums, _enums...)
 
 		if err != nil {
 			errs = append(errs, err)
 		}
 
-		structName := getStructName(typeKey, v, 1)
+		if len(v.AllOf) > 0 {
+			allOfFields, allOfSlices, allOfEnums, err := getAllOfFields(typeKey, v.AllOf, types, g.FormatMapping)
+			slices = append(slices, allOfSlices...)
+			enums = append(enums, allOfEnums...)
 
-		if err != nil {
-			errs = append(errs, err)
+			if err != nil {
+				errs = append(errs, err)
+			}
+
+			for name, f := range allOfFields {
+				if existing, ok := fields[name]; ok && existing.Type != f.Type {
+					errs = append(errs, fmt.Errorf("conflicting field %s in allOf for %s: %s vs %s", name, typeKey, existing.Type, f.Type))
+					continue
+				}
+
+				fields[name] = f
+			}
 		}
 
+		structName := getStructName(typeKey, v, 1)
+
 		s := StructMeta{
-			ID:     typeKey,
-			Name:   structName,
-			Fields: fields,
+			ID:      typeKey,
+			Name:    structName,
+			Fields:  fields,
+			Imports: importsForFields(fields),
 		}
 
 		structs[s.Name] = s
+		typeKeyToName[typeKey] = s.Name
+	}
+
+	order := make([]string, 0, len(typeKeyToName))
+	for _, typeKey := range topoSortTypeKeys(types) {
+		if name, ok := typeKeyToName[typeKey]; ok {
+			order = append(order, name)
+		}
 	}
 
 	if len(errs) > 0 {
-		return structs, slices, errors.New(joinErrors(errs))
+		return structs, slices, interfaces, enums, order, errors.New(joinErrors(errs))
 	}
 
-	return structs, slices, nil
+	return structs, slices, interfaces, enums, order, nil
+}
+
+// resolveExternalRefs walks every schema already known to types and, for any
+// $ref it finds that is neither a key of types nor a local ("#...") JSON
+// Pointer, fetches it through Resolver and merges the types it extracts back in. It
+// repeats until no new external refs turn up, so a fetched schema's own refs
+// are followed in turn. Resolved schemas are cached on the Extractor so a ref
+// used from multiple places is only fetched once.
+func (g *Extractor) resolveExternalRefs(types map[string]*jsonschema.Schema) error {
+	if g.Resolver == nil {
+		return nil
+	}
+
+	if g.refCache == nil {
+		g.refCache = map[string]*jsonschema.Schema{}
+	}
+
+	errs := []error{}
+	pending := getOrderedKeyNamesFromSchemaMap(types)
+
+	for i := 0; i < len(pending); i++ {
+		for _, ref := range collectReferences(types[pending[i]]) {
+			if _, ok := types[ref]; ok {
+				continue
+			}
+
+			if strings.HasPrefix(ref, "#") {
+				continue
+			}
+
+			resolved, ok := g.refCache[ref]
+			if !ok {
+				var err error
+				resolved, err = g.Resolver.Resolve(ref)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("resolving %s: %s", ref, err.Error()))
+					continue
+				}
+				rewriteReferences(resolved, ref)
+				g.refCache[ref] = resolved
+			}
+
+			// ExtractTypes() keys its result with pointer paths relative to
+			// resolved, e.g. "#" and "#/definitions/Foo". Since those paths
+			// are meaningless outside resolved's own document, namespace
+			// every key under the ref used to reach it (ref itself becomes
+			// the key for "#", resolved's own root) so they can't collide
+			// with identically-named types pulled in from elsewhere.
+			for k, v := range resolved.ExtractTypes() {
+				namespaced := ref + strings.TrimPrefix(k, "#")
+				if _, ok := types[namespaced]; !ok {
+					types[namespaced] = v
+					pending = append(pending, namespaced)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(joinErrors(errs))
+	}
+
+	return nil
+}
+
+// collectReferences returns every $ref found anywhere within v, including
+// nested properties, array items, additionalProperties/propertyNames
+// schemas, and allOf/oneOf/anyOf branches.
+func collectReferences(v *jsonschema.Schema) []string {
+	if v == nil {
+		return nil
+	}
+
+	var refs []string
+	if v.Reference != "" {
+		refs = append(refs, v.Reference)
+	}
+
+	for _, name := range getOrderedKeyNamesFromSchemaMap(v.Properties) {
+		refs = append(refs, collectReferences(v.Properties[name])...)
+	}
+
+	refs = append(refs, collectReferences(v.Items)...)
+	refs = append(refs, collectReferences(v.AdditionalProperties)...)
+	refs = append(refs, collectReferences(v.PropertyNames)...)
+
+	for _, b := range v.AllOf {
+		refs = append(refs, collectReferences(b)...)
+	}
+	for _, b := range v.OneOf {
+		refs = append(refs, collectReferences(b)...)
+	}
+	for _, b := range v.AnyOf {
+		refs = append(refs, collectReferences(b)...)
+	}
+
+	return refs
+}
+
+// rewriteReferences rewrites every local ("#/...") $ref found anywhere
+// within v to be namespaced under ref, mirroring how the types extracted
+// from v are namespaced when merged into the shared types map, so $refs
+// internal to a resolved external document still resolve after merging.
+func rewriteReferences(v *jsonschema.Schema, ref string) {
+	if v == nil {
+		return
+	}
+
+	if strings.HasPrefix(v.Reference, "#") {
+		v.Reference = ref + strings.TrimPrefix(v.Reference, "#")
+	}
+
+	for _, p := range v.Properties {
+		rewriteReferences(p, ref)
+	}
+
+	rewriteReferences(v.Items, ref)
+	rewriteReferences(v.AdditionalProperties, ref)
+	rewriteReferences(v.PropertyNames, ref)
+
+	for _, b := range v.AllOf {
+		rewriteReferences(b, ref)
+	}
+	for _, b := range v.OneOf {
+		rewriteReferences(b, ref)
+	}
+	for _, b := range v.AnyOf {
+		rewriteReferences(b, ref)
+	}
+}
+
+// topoSortTypeKeys orders typeKeys so that every type a struct depends on
+// (via a named object reference, an array of one, or a map's
+// additionalProperties value) is ordered before it.
+// A $ref cycle is broken by simply not following the back-edge into a type
+// still being visited — which is sound because getTypeForField already
+// emits object references as pointer fields, the only reason a cycle could
+// otherwise fail to compile as a Go value type.
+func topoSortTypeKeys(types map[string]*jsonschema.Schema) []string {
+	keys := getOrderedKeyNamesFromSchemaMap(types)
+	order := make([]string, 0, len(keys))
+	visited := map[string]bool{}
+	inProgress := map[string]bool{}
+
+	var visit func(key string)
+	visit = func(key string) {
+		if visited[key] || inProgress[key] {
+			return
+		}
+		inProgress[key] = true
+
+		if v := types[key]; v != nil {
+			for _, name := range getOrderedKeyNamesFromSchemaMap(v.Properties) {
+				if dep := referencedTypeKey(v.Properties[name], types); dep != "" {
+					visit(dep)
+				}
+			}
+		}
+
+		inProgress[key] = false
+		visited[key] = true
+		order = append(order, key)
+	}
+
+	for _, key := range keys {
+		visit(key)
+	}
+
+	return order
+}
+
+// referencedTypeKey returns the typeKey a field schema depends on, following
+// array items (at any depth of nesting) and a map's additionalProperties
+// value, or "" if the field isn't an object reference.
+func referencedTypeKey(schema *jsonschema.Schema, types map[string]*jsonschema.Schema) string {
+	if schema == nil {
+		return ""
+	}
+
+	if schema.Reference != "" {
+		if _, ok := types[schema.Reference]; ok {
+			return schema.Reference
+		}
+	}
+
+	if schema.Type == "array" {
+		return referencedTypeKey(schema.Items, types)
+	}
+
+	if schema.AdditionalProperties != nil {
+		return referencedTypeKey(schema.AdditionalProperties, types)
+	}
+
+	return ""
+}
+
+// getAllOfFields flattens the fields declared across every allOf branch of a
+// schema into a single field set. A branch may be a named reference (resolved
+// against types) or an inline schema. Branches that declare the same JSON
+// field with different golang types are reported as an error rather than
+// silently picking one.
+func getAllOfFields(parentTypeKey string, branches []*jsonschema.Schema, types map[string]*jsonschema.Schema, formatMapping map[string]string) (map[string]FieldMeta, []SliceMeta, []EnumMeta, error) {
+	fields := map[string]FieldMeta{}
+	var slices []SliceMeta
+	var enums []EnumMeta
+	errs := []error{}
+
+	for _, branch := range branches {
+		b := branch
+		if b.Reference != "" {
+			if t, ok := types[b.Reference]; ok {
+				b = t
+			}
+		}
+
+		branchFields, branchSlices, branchEnums, err := getFields(parentTypeKey, b.Properties, types, b.Required, formatMapping)
+		slices = append(slices, branchSlices...)
+		enums = append(enums, branchEnums...)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		for name, f := range branchFields {
+			if existing, ok := fields[name]; ok && existing.Type != f.Type {
+				errs = append(errs, fmt.Errorf("allOf conflict on field %s: %s vs %s", name, existing.Type, f.Type))
+				continue
+			}
+
+			fields[name] = f
+		}
+	}
+
+	if len(errs) > 0 {
+		return fields, slices, enums, errors.New(joinErrors(errs))
+	}
+
+	return fields, slices, enums, nil
+}
+
+// getInterfaceMeta builds the Go interface and discriminator plan for a
+// oneOf/anyOf schema. Each branch must resolve to a named reference; the
+// referenced struct becomes one of the interface's variants.
+func getInterfaceMeta(typeKey string, v *jsonschema.Schema, types map[string]*jsonschema.Schema) (InterfaceMeta, error) {
+	branches := v.OneOf
+	if len(branches) == 0 {
+		branches = v.AnyOf
+	}
+
+	name := getStructName(typeKey, v, 1)
+
+	variants := make([]string, 0, len(branches))
+	discriminatorValues := make([]string, 0, len(branches))
+	for _, b := range branches {
+		if b.Reference == "" {
+			return InterfaceMeta{}, fmt.Errorf("anonymous branch of %s is not supported, oneOf/anyOf branches must be named references", typeKey)
+		}
+
+		t, ok := types[b.Reference]
+		if !ok {
+			return InterfaceMeta{}, fmt.Errorf("%s references unknown branch %s", typeKey, b.Reference)
+		}
+
+		variant := getStructName(b.Reference, t, 1)
+		variants = append(variants, variant)
+
+		if v.Discriminator != nil {
+			discriminatorValues = append(discriminatorValues, discriminatorValue(b.Reference, t, v.Discriminator, variant))
+		}
+	}
+
+	im := InterfaceMeta{
+		ID:                  typeKey,
+		Name:                name,
+		DiscriminatorMethod: "is" + name,
+		Variants:            variants,
+	}
+
+	if v.Discriminator != nil && v.Discriminator.PropertyName != "" {
+		union := &UnionMeta{
+			Discriminator: v.Discriminator.PropertyName,
+			Variants:      make(map[string]string, len(variants)),
+		}
+
+		for i, variant := range variants {
+			union.Variants[discriminatorValues[i]] = variant
+		}
+
+		im.Union = union
+	}
+
+	return im, nil
+}
+
+// discriminatorValue determines the value the discriminator property carries
+// for a given oneOf/anyOf branch: an explicit mapping entry wins (the
+// standard OpenAPI discriminator.mapping convention), falling back to a
+// single-value enum declared on the branch's own discriminator property, and
+// finally the variant's golang name lower-cased as a last resort for
+// schemas that don't pin the value down explicitly.
+func discriminatorValue(ref string, branch *jsonschema.Schema, d *jsonschema.Discriminator, variantName string) string {
+	for value, mappedRef := range d.Mapping {
+		if mappedRef == ref {
+			return value
+		}
+	}
+
+	if prop, ok := branch.Properties[d.PropertyName]; ok && len(prop.Enum) > 0 {
+		return fmt.Sprint(prop.Enum[0])
+	}
+
+	return strings.ToLower(variantName)
 }
 
 func joinErrors(errs []error) string {
@@ -90,9 +740,10 @@ func getOrderedKeyNamesFromSchemaMap(m map[string]*jsonschema.Schema) []string {
 	return keys
 }
 
-func getFields(parentTypeKey string, properties map[string]*jsonschema.Schema, types map[string]*jsonschema.Schema, requiredFields []string) (map[string]FieldMeta, []SliceMeta, error) {
+func getFields(parentTypeKey string, properties map[string]*jsonschema.Schema, types map[string]*jsonschema.Schema, requiredFields []string, formatMapping map[string]string) (map[string]FieldMeta, []SliceMeta, []EnumMeta, error) {
 	fields := map[string]FieldMeta{}
 	var slices []SliceMeta
+	var enums []EnumMeta
 
 	missingTypes := []string{}
 	errors := []error{}
@@ -101,7 +752,7 @@ func getFields(parentTypeKey string, properties map[string]*jsonschema.Schema, t
 		v := properties[fieldName]
 
 		golangName := getGolangName(fieldName)
-		meta, err := getTypeForField(parentTypeKey, fieldName, golangName, v, types, true)
+		meta, err := getTypeForField(parentTypeKey, fieldName, golangName, v, types, true, formatMapping)
 
 		if err != nil {
 			missingTypes = append(missingTypes, golangName)
@@ -115,13 +766,16 @@ func getFields(parentTypeKey string, properties map[string]*jsonschema.Schema, t
 		if meta.Slice != nil {
 			slices = append(slices, *meta.Slice)
 		}
+		if meta.Enum != nil {
+			enums = append(enums, *meta.Enum)
+		}
 	}
 
 	if len(missingTypes) > 0 {
-		return fields, slices, fmt.Errorf("missing types for %s with errors %s", strings.Join(missingTypes, ","), joinErrors(errors))
+		return fields, slices, enums, fmt.Errorf("missing types for %s with errors %s", strings.Join(missingTypes, ","), joinErrors(errors))
 	}
 
-	return fields, slices, nil
+	return fields, slices, enums, nil
 }
 
 func contains(s []string, e string) bool {
@@ -133,17 +787,30 @@ func contains(s []string, e string) bool {
 	return false
 }
 
-func getTypeForField(parentTypeKey string, fieldName string, fieldGoName string, schema *jsonschema.Schema, types map[string]*jsonschema.Schema, pointer bool) (meta FieldMeta, err error) {
+func getTypeForField(parentTypeKey string, fieldName string, fieldGoName string, schema *jsonschema.Schema, types map[string]*jsonschema.Schema, pointer bool, formatMapping map[string]string) (meta FieldMeta, err error) {
 	majorType := schema.Type
 	subType := ""
 
+	meta.Nullable = schema.Nullable
+
 	// Look up by named reference.
 	if schema.Reference != "" {
 		if t, ok := types[schema.Reference]; ok {
-			sn := getStructName(schema.Reference, t, 1)
-
-			majorType = "object"
-			subType = sn
+			if len(t.OneOf) > 0 || len(t.AnyOf) > 0 {
+				im, ierr := getInterfaceMeta(schema.Reference, t, types)
+				if ierr != nil {
+					return meta, ierr
+				}
+
+				majorType = "object"
+				subType = im.Name
+				meta.Union = im.Union
+			} else {
+				sn := getStructName(schema.Reference, t, 1)
+
+				majorType = "object"
+				subType = sn
+			}
 		}
 	}
 
@@ -157,15 +824,53 @@ func getTypeForField(parentTypeKey string, fieldName string, fieldGoName string,
 		}
 	}
 
+	// An inline oneOf/anyOf on the field itself (no named reference) becomes
+	// an interface keyed under the field's own path.
+	if subType == "" && (len(schema.OneOf) > 0 || len(schema.AnyOf) > 0) {
+		im, ierr := getInterfaceMeta(parentTypeKey+"/properties/"+fieldName, schema, types)
+		if ierr != nil {
+			return meta, ierr
+		}
+
+		majorType = "object"
+		subType = im.Name
+		meta.Union = im.Union
+	}
+
+	// An object with no declared properties whose additionalProperties names
+	// its own schema becomes a map[string]T rather than an empty struct. An
+	// object that declares properties, or has no additionalProperties schema,
+	// is not turned into a map. The value schema is resolved the same way an array's items
+	// schema is, so a nested object still gets its own StructMeta.
+	if subType == "" && majorType == "object" && len(schema.Properties) == 0 && schema.AdditionalProperties != nil {
+		valueMeta, verr := getTypeForField(parentTypeKey, fieldName, fieldGoName, schema.AdditionalProperties, types, true, formatMapping)
+		if verr != nil {
+			return meta, verr
+		}
+
+		meta.Map = &MapMeta{
+			ValueType: valueMeta.Type,
+			MinProps:  schema.MinProperties,
+			MaxProps:  schema.MaxProperties,
+		}
+
+		if schema.PropertyNames != nil {
+			meta.Map.PropertyNamesPattern = schema.PropertyNames.Pattern
+		}
+
+		majorType = "map"
+		subType = valueMeta.Type
+	}
+
 	// Find named array references.
 	if majorType == "array" {
-		s, _ := getTypeForField(parentTypeKey, fieldName, fieldGoName, schema.Items, types, false)
+		s, _ := getTypeForField(parentTypeKey, fieldName, fieldGoName, schema.Items, types, false, formatMapping)
 		meta.Slice = &SliceMeta{s.Type, schema.MinItems, schema.MaxItems}
 
 		subType = s.Type
 	}
 
-	typName, err := getPrimitiveTypeName(majorType, subType, pointer)
+	typName, err := getPrimitiveTypeName(majorType, subType, schema.Format, formatMapping, pointer)
 
 	switch typName {
 	case "int", "float64":
@@ -179,9 +884,27 @@ func getTypeForField(parentTypeKey string, fieldName string, fieldGoName string,
 	case "string":
 		if schema.MinLength != 0 ||
 			schema.MaxLength != 0 ||
-			schema.Pattern != "" {
-			meta.String = &StringMeta{schema.MinLength, schema.MaxLength, schema.Pattern}
+			schema.Pattern != "" ||
+			schema.Format != "" {
+			meta.String = &StringMeta{schema.MinLength, schema.MaxLength, schema.Pattern, schema.Format}
+		}
+	case "[]byte":
+		if schema.Format == "byte" || schema.Format == "binary" {
+			meta.Format = &FormatMeta{Format: schema.Format}
+		}
+	}
+
+	// A constrained set of values becomes a named type with typed constants,
+	// rather than a bare string/int.
+	if len(schema.Enum) > 0 && (typName == "string" || typName == "int") {
+		parentName := "Root"
+		if parentType, ok := types[parentTypeKey]; ok {
+			parentName = getStructName(parentTypeKey, parentType, 1)
 		}
+
+		em := getEnumMeta(parentName+fieldGoName, typName, schema.Enum)
+		meta.Enum = &em
+		typName = em.Name
 	}
 
 	if err != nil {
@@ -195,7 +918,77 @@ func getTypeForField(parentTypeKey string, fieldName string, fieldGoName string,
 	return
 }
 
-func getPrimitiveTypeName(schemaType string, subType string, pointer bool) (name string, err error) {
+// getEnumMeta builds the named type and typed constants for a field
+// constrained by the "enum" keyword. name should already be disambiguated
+// against other enums in the schema, e.g. by combining the parent struct
+// name with the field's own golang name.
+func getEnumMeta(name string, baseType string, values []interface{}) EnumMeta {
+	em := EnumMeta{
+		Name:     name,
+		BaseType: baseType,
+	}
+
+	for _, v := range values {
+		em.Values = append(em.Values, EnumValue{
+			GoName: name + enumValueLabel(v),
+			Raw:    v,
+		})
+	}
+
+	return em
+}
+
+// enumValueLabel builds the golang name fragment for a single enum value.
+// Numeric values are formatted directly rather than routed through
+// getGolangName, whose "-" splitting would otherwise collapse a negative
+// number and its positive counterpart (e.g. -1 and 1) onto the same name.
+func enumValueLabel(v interface{}) string {
+	switch n := v.(type) {
+	case int:
+		return intEnumLabel(n)
+	case int64:
+		return intEnumLabel(int(n))
+	case float64:
+		return floatEnumLabel(n)
+	}
+
+	return getGolangName(fmt.Sprint(v))
+}
+
+// intEnumLabel formats a whole-number enum value, prefixing negative values
+// with "Neg" instead of letting their sign reach getGolangName's splitter.
+func intEnumLabel(n int) string {
+	if n < 0 {
+		return "Neg" + strconv.Itoa(-n)
+	}
+
+	return strconv.Itoa(n)
+}
+
+// floatEnumLabel formats a numeric enum value the same way intEnumLabel
+// does for whole numbers, but also guards fractional values: it prefixes
+// negatives with "Neg" and spells the decimal point out as "Dot" rather
+// than letting either reach getGolangName's "-"/"." splitter, which would
+// otherwise collapse e.g. -1.5 and 1.5 onto the same golang name.
+func floatEnumLabel(n float64) string {
+	if n == float64(int(n)) {
+		return intEnumLabel(int(n))
+	}
+
+	prefix := ""
+	if n < 0 {
+		prefix = "Neg"
+		n = -n
+	}
+
+	return prefix + strings.Replace(strconv.FormatFloat(n, 'f', -1, 64), ".", "Dot", 1)
+}
+
+// getPrimitiveTypeName maps a JSON schema type/format pair onto a golang type
+// name. formatMapping is consulted first, so callers can override any format
+// (including the built-in date-time/byte/binary/uri ones below) with their
+// own golang type.
+func getPrimitiveTypeName(schemaType string, subType string, format string, formatMapping map[string]string, pointer bool) (name string, err error) {
 	switch schemaType {
 	case "array":
 		if subType == "" {
@@ -208,6 +1001,8 @@ func getPrimitiveTypeName(schemaType string, subType string, pointer bool) (name
 		return "int", nil
 	case "number":
 		return "float64", nil
+	case "map":
+		return "map[string]" + subType, nil
 	case "null":
 		return "nil", nil
 	case "object":
@@ -217,12 +1012,76 @@ func getPrimitiveTypeName(schemaType string, subType string, pointer bool) (name
 
 		return subType, nil
 	case "string":
+		if mapped, ok := formatMapping[format]; ok {
+			return mapped, nil
+		}
+
+		switch format {
+		case "date-time":
+			return "time.Time", nil
+		case "byte", "binary":
+			return "[]byte", nil
+		case "uri":
+			return "*url.URL", nil
+		case "ipv4", "ipv6":
+			return "net.IP", nil
+		}
+
 		return "string", nil
 	}
 
 	return "undefined", fmt.Errorf("failed to get a primitive type for schemaType %s and subtype %s", schemaType, subType)
 }
 
+// importsForFields collects the golang import paths needed to compile a
+// struct, derived from the types its fields resolved to.
+func importsForFields(fields map[string]FieldMeta) []string {
+	seen := map[string]bool{}
+	var imports []string
+
+	for _, f := range fields {
+		imp, ok := requiredImportsByType[baseTypeName(f.Type)]
+		if !ok || seen[imp] {
+			continue
+		}
+
+		seen[imp] = true
+		imports = append(imports, imp)
+	}
+
+	sort.Strings(imports)
+
+	return imports
+}
+
+// baseTypeName strips the pointer, slice and map wrappers a generated field
+// type can be dressed in, leaving the leaf type requiredImportsByType keys
+// on, e.g. "map[string]time.Time" and "*[]time.Time" both become "time.Time".
+func baseTypeName(typ string) string {
+	for {
+		switch {
+		case strings.HasPrefix(typ, "*"):
+			typ = strings.TrimPrefix(typ, "*")
+		case strings.HasPrefix(typ, "map[string]"):
+			typ = strings.TrimPrefix(typ, "map[string]")
+		case strings.HasPrefix(typ, "[]"):
+			typ = strings.TrimPrefix(typ, "[]")
+		default:
+			return typ
+		}
+	}
+}
+
+// requiredImportsByType maps a generated golang type name to the import path
+// it needs.
+var requiredImportsByType = map[string]string{
+	"time.Time":       "time",
+	"url.URL":         "net/url",
+	"net.IP":          "net",
+	"uuid.UUID":       "github.com/google/uuid",
+	"json.RawMessage": "encoding/json",
+}
+
 // getStructName makes a golang struct name from an input reference in the form of #/definitions/address
 // The parts refers to the number of segments from the end to take as the name.
 func getStructName(reference string, structType *jsonschema.Schema, n int) string {
@@ -315,6 +1174,9 @@ type StructMeta struct {
 	// The golang name, e.g. "Address"
 	Name   string
 	Fields map[string]FieldMeta
+	// Imports lists the import paths required to compile this struct, e.g.
+	// "time" for a field typed time.Time.
+	Imports []string
 }
 
 // FieldMeta defines the data required to generate a field in Go.
@@ -327,9 +1189,87 @@ type FieldMeta struct {
 	Type string
 	// Required is set to true when the field is required.
 	Required bool
+	// Nullable is set to true when the schema allows this field to be null
+	// independent of whether it is required. It is read straight off
+	// jsonschema.Schema's OpenAPI-style "nullable" keyword rather than
+	// derived from a JSON-Schema-proper "type": ["string","null"] array,
+	// since Schema.Type is a single string throughout this library and has
+	// no array form to parse.
+	Nullable bool
 	Slice    *SliceMeta
 	String   *StringMeta
 	Number   *NumberMeta
+	// Format is set for a byte/binary string field, so the generated code
+	// knows whether a []byte should be base64-encoded or written raw.
+	Format *FormatMeta
+	// Union is set when Type names an InterfaceMeta backed by a discriminated
+	// oneOf/anyOf, so the generated UnmarshalJSON knows how to pick a variant.
+	Union *UnionMeta
+	// Enum is set when Type names an EnumMeta, generated from the "enum"
+	// keyword rather than a bare string/int.
+	Enum *EnumMeta
+	// Map is set when Type is a map[string]T generated from
+	// additionalProperties.
+	Map *MapMeta
+}
+
+// MapMeta defines the data required to generate a map[string]T field from an
+// "additionalProperties" schema.
+type MapMeta struct {
+	// ValueType is the golang type of the map's values.
+	ValueType string
+	MinProps  int
+	MaxProps  int
+	// PropertyNamesPattern is propertyNames.pattern verbatim, carried through
+	// for downstream validator generation.
+	PropertyNamesPattern string
+}
+
+// EnumMeta defines a named string/int alias with typed constants, generated
+// for a field constrained by the JSON schema "enum" keyword.
+type EnumMeta struct {
+	// The golang name of the generated type, e.g. "ProductStatus".
+	Name string
+	// BaseType is the underlying golang type, "string" or "int".
+	BaseType string
+	Values   []EnumValue
+}
+
+// EnumValue is a single typed constant belonging to an EnumMeta.
+type EnumValue struct {
+	// The golang constant name, e.g. "ProductStatusActive".
+	GoName string
+	// Raw is the literal enum value from the schema.
+	Raw interface{}
+}
+
+// InterfaceMeta defines the data required to generate a Go interface for a
+// polymorphic (oneOf/anyOf) JSON schema, along with the discriminator method
+// each variant struct implements to satisfy it.
+type InterfaceMeta struct {
+	// The ID within the JSON schema, e.g. #/definitions/pet
+	ID string
+	// The golang name, e.g. "Pet"
+	Name string
+	// DiscriminatorMethod is the unexported marker method every variant
+	// implements to satisfy this interface, e.g. "isPet".
+	DiscriminatorMethod string
+	// Variants lists the golang struct names that implement this interface.
+	Variants []string
+	// Union is set when the schema carries a discriminator keyword, and
+	// describes how to dispatch unmarshalling to the right variant.
+	Union *UnionMeta
+}
+
+// UnionMeta captures the information needed to generate an UnmarshalJSON that
+// dispatches on a discriminator property to the correct variant.
+type UnionMeta struct {
+	// Discriminator is the JSON property name used to select the variant,
+	// e.g. "petType".
+	Discriminator string
+	// Variants maps the discriminator value to the golang struct name that
+	// should be unmarshalled for it.
+	Variants map[string]string
 }
 
 type SliceMeta struct {
@@ -342,6 +1282,16 @@ type StringMeta struct {
 	MinLength int
 	MaxLength int
 	Pattern   string
+	// Format is the JSON schema "format" keyword verbatim, e.g. "email". It is
+	// only recorded here when the field's golang type stays string.
+	Format string
+}
+
+// FormatMeta carries the original string format for a field whose golang
+// type was mapped to []byte, so a renderer knows whether to base64-encode.
+type FormatMeta struct {
+	// Format is "byte" (base64-encoded) or "binary" (raw bytes).
+	Format string
 }
 
 type NumberMeta struct {
@@ -1,8 +1,11 @@
 package generate
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/crzmshrm/typextract/jsonschema"
 )
@@ -17,6 +20,9 @@ func TestExtractor_CreateStructs(t *testing.T) {
 		fields  fields
 		want    map[string]StructMeta
 		want1   []SliceMeta
+		want2   map[string]InterfaceMeta
+		want3   []EnumMeta
+		want4   []string
 		wantErr bool
 	}{
 		{
@@ -26,6 +32,9 @@ func TestExtractor_CreateStructs(t *testing.T) {
 			},
 			want:    expectedTypes1,
 			want1:   []SliceMeta{SliceMeta{"string", 1, 0}},
+			want2:   map[string]InterfaceMeta{},
+			want3:   nil,
+			want4:   []string{"Product"},
 			wantErr: false,
 		},
 	}
@@ -34,7 +43,7 @@ func TestExtractor_CreateStructs(t *testing.T) {
 			g := &Extractor{
 				schema: tt.fields.schema,
 			}
-			got, got1, err := g.CreateStructs()
+			got, got1, got2, got3, got4, err := g.CreateStructs()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Extractor.CreateStructs() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -45,10 +54,479 @@ func TestExtractor_CreateStructs(t *testing.T) {
 			if !reflect.DeepEqual(got1, tt.want1) {
 				t.Errorf("Extractor.CreateStructs() got1 = %v, want %v", got1, tt.want1)
 			}
+			if !reflect.DeepEqual(got2, tt.want2) {
+				t.Errorf("Extractor.CreateStructs() got2 = %v, want %v", got2, tt.want2)
+			}
+			if !reflect.DeepEqual(got3, tt.want3) {
+				t.Errorf("Extractor.CreateStructs() got3 = %v, want %v", got3, tt.want3)
+			}
+			if !reflect.DeepEqual(got4, tt.want4) {
+				t.Errorf("Extractor.CreateStructs() got4 = %v, want %v", got4, tt.want4)
+			}
 		})
 	}
 }
 
+func TestGetAllOfFields(t *testing.T) {
+	types := map[string]*jsonschema.Schema{
+		"#/definitions/Animal": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"name": {Type: "string"},
+			},
+			Required: []string{"name"},
+		},
+	}
+
+	branches := []*jsonschema.Schema{
+		{Reference: "#/definitions/Animal"},
+		{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"breed": {Type: "string"},
+			},
+			Required: []string{"breed"},
+		},
+	}
+
+	fields, _, _, err := getAllOfFields("#/definitions/Dog", branches, types, map[string]string{})
+	if err != nil {
+		t.Fatalf("getAllOfFields() error = %v", err)
+	}
+
+	if f, ok := fields["Name"]; !ok || f.Type != "string" || !f.Required {
+		t.Errorf("expected Name flattened in from the Animal branch, got %#v", fields)
+	}
+	if f, ok := fields["Breed"]; !ok || f.Type != "string" || !f.Required {
+		t.Errorf("expected Breed from the inline branch, got %#v", fields)
+	}
+}
+
+func TestGetInterfaceMeta_Discriminator(t *testing.T) {
+	types := map[string]*jsonschema.Schema{
+		"#/definitions/Dog": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"petType": {Type: "string", Enum: []interface{}{"dog"}},
+			},
+		},
+		"#/definitions/Cat": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"petType": {Type: "string", Enum: []interface{}{"cat"}},
+			},
+		},
+	}
+
+	pet := &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{Reference: "#/definitions/Dog"},
+			{Reference: "#/definitions/Cat"},
+		},
+		Discriminator: &jsonschema.Discriminator{PropertyName: "petType"},
+	}
+
+	im, err := getInterfaceMeta("#/definitions/Pet", pet, types)
+	if err != nil {
+		t.Fatalf("getInterfaceMeta() error = %v", err)
+	}
+
+	want := []string{"Dog", "Cat"}
+	if !reflect.DeepEqual(im.Variants, want) {
+		t.Errorf("getInterfaceMeta() Variants = %v, want %v", im.Variants, want)
+	}
+
+	wantUnion := map[string]string{"dog": "Dog", "cat": "Cat"}
+	if im.Union == nil || !reflect.DeepEqual(im.Union.Variants, wantUnion) {
+		t.Errorf("getInterfaceMeta() Union.Variants = %#v, want %v (dispatch must key on the discriminator value, not the struct name)", im.Union, wantUnion)
+	}
+}
+
+func TestExtractor_ImportType(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+
+	type Person struct {
+		Address
+		Name      string    `json:"name"`
+		Nickname  string    `json:"nickname,omitempty"`
+		CreatedAt time.Time `json:"createdAt"`
+		Tags      []string  `json:"tags"`
+		Secret    string    `json:"-"`
+	}
+
+	g := New(nil)
+	structs, slices, err := g.ImportType(Person{})
+	if err != nil {
+		t.Fatalf("ImportType() error = %v", err)
+	}
+
+	person, ok := structs["Person"]
+	if !ok {
+		t.Fatalf("expected a Person struct, got %#v", structs)
+	}
+
+	if f, ok := person.Fields["City"]; !ok || f.Type != "string" {
+		t.Errorf("expected City promoted from the embedded Address, got %#v", person.Fields)
+	}
+	if f, ok := person.Fields["Name"]; !ok || !f.Required {
+		t.Errorf("expected Name to be required (no omitempty), got %#v", person.Fields["Name"])
+	}
+	if f, ok := person.Fields["Nickname"]; !ok || f.Required {
+		t.Errorf("expected Nickname to be optional (omitempty), got %#v", person.Fields["Nickname"])
+	}
+	if f, ok := person.Fields["CreatedAt"]; !ok || f.Type != "time.Time" {
+		t.Errorf("expected CreatedAt typed as time.Time, got %#v", person.Fields["CreatedAt"])
+	}
+	if _, ok := person.Fields["Secret"]; ok {
+		t.Errorf("expected Secret (json:\"-\") to be skipped, got %#v", person.Fields)
+	}
+	if !reflect.DeepEqual(person.Imports, []string{"time"}) {
+		t.Errorf("expected Person.Imports = [time], got %v", person.Imports)
+	}
+
+	var wantSlice bool
+	for _, s := range slices {
+		if s.ElemType == "string" {
+			wantSlice = true
+		}
+	}
+	if !wantSlice {
+		t.Errorf("expected a []string SliceMeta for Tags, got %v", slices)
+	}
+}
+
+func TestExtractor_ImportType_Interface(t *testing.T) {
+	type Event struct {
+		Payload interface{} `json:"payload"`
+	}
+
+	g := New(nil)
+	structs, _, err := g.ImportType(Event{})
+	if err != nil {
+		t.Fatalf("ImportType() error = %v", err)
+	}
+
+	event, ok := structs["Event"]
+	if !ok {
+		t.Fatalf("expected an Event struct, got %#v", structs)
+	}
+	if f, ok := event.Fields["Payload"]; !ok || f.Type != "interface{}" {
+		t.Errorf("expected Payload typed as interface{}, got %#v", event.Fields["Payload"])
+	}
+}
+
+func TestExtractor_ImportType_RawMessageImport(t *testing.T) {
+	type Event struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+
+	g := New(nil)
+	structs, _, err := g.ImportType(Event{})
+	if err != nil {
+		t.Fatalf("ImportType() error = %v", err)
+	}
+
+	event, ok := structs["Event"]
+	if !ok {
+		t.Fatalf("expected an Event struct, got %#v", structs)
+	}
+	if f, ok := event.Fields["Payload"]; !ok || f.Type != "json.RawMessage" {
+		t.Errorf("expected Payload typed as json.RawMessage, got %#v", event.Fields["Payload"])
+	}
+	if !reflect.DeepEqual(event.Imports, []string{"encoding/json"}) {
+		t.Errorf("expected Event.Imports = [encoding/json], got %v", event.Imports)
+	}
+}
+
+func TestExtractor_ImportType_NonStringMapKey(t *testing.T) {
+	type Tally struct {
+		Counts map[int]string `json:"counts"`
+	}
+
+	g := New(nil)
+	_, _, err := g.ImportType(Tally{})
+	if err == nil {
+		t.Fatal("expected an error for a map with a non-string key, got nil")
+	}
+}
+
+func TestGetPrimitiveTypeName_Formats(t *testing.T) {
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"date-time", "time.Time"},
+		{"byte", "[]byte"},
+		{"binary", "[]byte"},
+		{"uri", "*url.URL"},
+		{"ipv4", "net.IP"},
+		{"ipv6", "net.IP"},
+		{"uuid", "uuid.UUID"},
+		{"email", "string"},
+	}
+
+	formatMapping := map[string]string{"uuid": "uuid.UUID"}
+
+	for _, c := range cases {
+		got, err := getPrimitiveTypeName("string", "", c.format, formatMapping, false)
+		if err != nil {
+			t.Fatalf("getPrimitiveTypeName(%q) error = %v", c.format, err)
+		}
+		if got != c.want {
+			t.Errorf("getPrimitiveTypeName(%q) = %s, want %s", c.format, got, c.want)
+		}
+	}
+}
+
+func TestImportsForFields(t *testing.T) {
+	fields := map[string]FieldMeta{
+		"CreatedAt": {Type: "time.Time"},
+		"ID":        {Type: "uuid.UUID"},
+		"Count":     {Type: "int"},
+	}
+
+	got := importsForFields(fields)
+	want := []string{"github.com/google/uuid", "time"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("importsForFields() = %v, want %v", got, want)
+	}
+}
+
+func TestImportsForFields_MapValue(t *testing.T) {
+	fields := map[string]FieldMeta{
+		"Timestamps": {Type: "map[string]time.Time"},
+	}
+
+	got := importsForFields(fields)
+	want := []string{"time"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("importsForFields() = %v, want %v", got, want)
+	}
+}
+
+func TestGetTypeForField_Nullable(t *testing.T) {
+	schema := &jsonschema.Schema{Type: "string", Nullable: true}
+
+	meta, err := getTypeForField("#", "name", "Name", schema, map[string]*jsonschema.Schema{}, true, map[string]string{})
+	if err != nil {
+		t.Fatalf("getTypeForField() error = %v", err)
+	}
+	if !meta.Nullable {
+		t.Errorf("getTypeForField() Nullable = false, want true")
+	}
+}
+
+func TestGetEnumMeta(t *testing.T) {
+	em := getEnumMeta("Status", "string", []interface{}{"active", "inactive"})
+
+	want := []EnumValue{
+		{GoName: "StatusActive", Raw: "active"},
+		{GoName: "StatusInactive", Raw: "inactive"},
+	}
+
+	if !reflect.DeepEqual(em.Values, want) {
+		t.Errorf("getEnumMeta() Values = %#v, want %#v", em.Values, want)
+	}
+}
+
+func TestGetEnumMeta_NegativeIntegers(t *testing.T) {
+	em := getEnumMeta("StatusCode", "int", []interface{}{-1, 1})
+
+	if len(em.Values) != 2 {
+		t.Fatalf("getEnumMeta() produced %d values, want 2", len(em.Values))
+	}
+
+	if em.Values[0].GoName == em.Values[1].GoName {
+		t.Errorf("getEnumMeta() produced colliding GoNames for -1 and 1: %#v", em.Values)
+	}
+}
+
+func TestGetEnumMeta_NegativeFloats(t *testing.T) {
+	em := getEnumMeta("Ratio", "number", []interface{}{-1.5, 1.5})
+
+	if len(em.Values) != 2 {
+		t.Fatalf("getEnumMeta() produced %d values, want 2", len(em.Values))
+	}
+
+	if em.Values[0].GoName == em.Values[1].GoName {
+		t.Errorf("getEnumMeta() produced colliding GoNames for -1.5 and 1.5: %#v", em.Values)
+	}
+}
+
+func TestTopoSortTypeKeys_Cycle(t *testing.T) {
+	types := map[string]*jsonschema.Schema{
+		"#/definitions/A": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"b": {Reference: "#/definitions/B"},
+			},
+		},
+		"#/definitions/B": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"a": {Reference: "#/definitions/A"},
+			},
+		},
+	}
+
+	order := topoSortTypeKeys(types)
+
+	if len(order) != 2 {
+		t.Fatalf("topoSortTypeKeys() = %v, want 2 entries", order)
+	}
+}
+
+func TestTopoSortTypeKeys_AdditionalPropertiesDependency(t *testing.T) {
+	types := map[string]*jsonschema.Schema{
+		"#/definitions/Tags": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"byName": {
+					Type:                 "object",
+					AdditionalProperties: &jsonschema.Schema{Reference: "#/definitions/Tag"},
+				},
+			},
+		},
+		"#/definitions/Tag": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"label": {Type: "string"},
+			},
+		},
+	}
+
+	order := topoSortTypeKeys(types)
+
+	tagIdx := indexOf(order, "#/definitions/Tag")
+	tagsIdx := indexOf(order, "#/definitions/Tags")
+	if tagIdx == -1 || tagsIdx == -1 || tagIdx > tagsIdx {
+		t.Errorf("expected #/definitions/Tag before #/definitions/Tags, got order %v", order)
+	}
+}
+
+func indexOf(s []string, v string) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+	return -1
+}
+
+type fakeResolver struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+func (f *fakeResolver) Resolve(ref string) (*jsonschema.Schema, error) {
+	s, ok := f.schemas[ref]
+	if !ok {
+		return nil, fmt.Errorf("unknown ref %s", ref)
+	}
+	return s, nil
+}
+
+func TestResolveExternalRefs_Namespacing(t *testing.T) {
+	resolver := &fakeResolver{
+		schemas: map[string]*jsonschema.Schema{
+			"other.json#/definitions/Error": {
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"id": {Type: "string"},
+				},
+			},
+		},
+	}
+
+	g := &Extractor{Resolver: resolver}
+
+	types := map[string]*jsonschema.Schema{
+		"#/definitions/Error": {
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"code": {Type: "integer"},
+			},
+		},
+		"#/properties/err": {
+			Reference: "other.json#/definitions/Error",
+		},
+	}
+
+	if err := g.resolveExternalRefs(types); err != nil {
+		t.Fatalf("resolveExternalRefs() error = %v", err)
+	}
+
+	local, ok := types["#/definitions/Error"]
+	if !ok || local.Properties["code"] == nil {
+		t.Fatalf("local #/definitions/Error was clobbered by the external one, got %#v", types["#/definitions/Error"])
+	}
+
+	external, ok := types["other.json#/definitions/Error"]
+	if !ok || external.Properties["id"] == nil {
+		t.Fatalf("expected the external schema namespaced under its own ref, got %#v", types)
+	}
+}
+
+func TestResolveExternalRefs_ThroughAdditionalProperties(t *testing.T) {
+	resolver := &fakeResolver{
+		schemas: map[string]*jsonschema.Schema{
+			"other.json#/definitions/Tag": {
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"label": {Type: "string"},
+				},
+			},
+		},
+	}
+
+	g := &Extractor{Resolver: resolver}
+
+	types := map[string]*jsonschema.Schema{
+		"#/definitions/Tags": {
+			Type:                 "object",
+			AdditionalProperties: &jsonschema.Schema{Reference: "other.json#/definitions/Tag"},
+		},
+	}
+
+	if err := g.resolveExternalRefs(types); err != nil {
+		t.Fatalf("resolveExternalRefs() error = %v", err)
+	}
+
+	if _, ok := types["other.json#/definitions/Tag"]; !ok {
+		t.Fatalf("expected the ref reachable only through additionalProperties to be resolved, got %#v", types)
+	}
+}
+
+func TestGetTypeForField_AdditionalPropertiesMap(t *testing.T) {
+	schema := &jsonschema.Schema{
+		Type:                 "object",
+		AdditionalProperties: &jsonschema.Schema{Type: "integer"},
+		MinProperties:        1,
+		MaxProperties:        10,
+		PropertyNames:        &jsonschema.Schema{Pattern: "^[a-z]+$"},
+	}
+
+	meta, err := getTypeForField("#", "counts", "Counts", schema, map[string]*jsonschema.Schema{}, true, map[string]string{})
+	if err != nil {
+		t.Fatalf("getTypeForField() error = %v", err)
+	}
+
+	if meta.Type != "map[string]int" {
+		t.Errorf("getTypeForField() Type = %s, want map[string]int", meta.Type)
+	}
+
+	if meta.Map == nil {
+		t.Fatalf("expected Map to be set")
+	}
+
+	if meta.Map.ValueType != "int" || meta.Map.MinProps != 1 || meta.Map.MaxProps != 10 || meta.Map.PropertyNamesPattern != "^[a-z]+$" {
+		t.Errorf("getTypeForField() Map = %#v", meta.Map)
+	}
+}
+
 var (
 	schema1 = jsonschema.ParseMust(`{
 		"$schema": "http://json-schema.org/draft-04/schema#",